@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gopkg.in/yaml.v3"
+)
+
+// chaosRulesFiredTotal counts how many times each named fault has fired.
+var chaosRulesFiredTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "chaos_rules_fired_total",
+		Help: "Total number of times a chaos rule fired, by rule name and action",
+	},
+	[]string{"rule", "action"},
+)
+
+// ChaosAction is a single action a fault can take once it is selected to fire.
+type ChaosAction struct {
+	Type     string        `yaml:"type" json:"type"` // panic, sleep, abort, cpu_burn, memory_leak, latency_percentile
+	Status   int           `yaml:"status,omitempty" json:"status,omitempty"`
+	Min      time.Duration `yaml:"min,omitempty" json:"min,omitempty"`
+	Max      time.Duration `yaml:"max,omitempty" json:"max,omitempty"`
+	Duration time.Duration `yaml:"duration,omitempty" json:"duration,omitempty"`
+	MemoryMB int           `yaml:"memory_mb,omitempty" json:"memory_mb,omitempty"`
+	P50      time.Duration `yaml:"p50,omitempty" json:"p50,omitempty"`
+	P99      time.Duration `yaml:"p99,omitempty" json:"p99,omitempty"`
+}
+
+// ChaosRule is a single named fault: when it matches a request, it fires with
+// the given probability and runs its action.
+type ChaosRule struct {
+	Name        string      `yaml:"name" json:"name"`
+	PathMatch   string      `yaml:"path_match,omitempty" json:"path_match,omitempty"`
+	Method      string      `yaml:"method,omitempty" json:"method,omitempty"`
+	Probability float64     `yaml:"probability" json:"probability"`
+	Action      ChaosAction `yaml:"action" json:"action"`
+
+	compiledPath *regexp.Regexp
+}
+
+// ChaosConfig is the top-level YAML document describing the fault set.
+type ChaosConfig struct {
+	Rules []ChaosRule `yaml:"rules" json:"rules"`
+}
+
+// ChaosEngine evaluates chaos rules against incoming requests and applies
+// their actions. It is safe for concurrent use.
+type ChaosEngine struct {
+	mu    sync.RWMutex
+	rules []ChaosRule
+}
+
+// newChaosEngine builds an engine from CHAOS_CONFIG_PATH, if set, otherwise
+// returns an engine with no rules configured.
+func newChaosEngine() *ChaosEngine {
+	e := &ChaosEngine{}
+
+	path := os.Getenv("CHAOS_CONFIG_PATH")
+	if path == "" {
+		return e
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logJSON(LogEntry{
+			Level:   "WARN",
+			Message: fmt.Sprintf("Failed to read chaos config %s: %v", path, err),
+		})
+		return e
+	}
+
+	if err := e.loadYAML(data); err != nil {
+		logJSON(LogEntry{
+			Level:   "WARN",
+			Message: fmt.Sprintf("Failed to parse chaos config %s: %v", path, err),
+		})
+	}
+
+	return e
+}
+
+// loadYAML replaces the rule set from a YAML document, compiling each rule's
+// path matcher up front so Apply doesn't pay regexp compilation cost per request.
+func (e *ChaosEngine) loadYAML(data []byte) error {
+	var cfg ChaosConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse chaos config: %w", err)
+	}
+
+	for i := range cfg.Rules {
+		if cfg.Rules[i].PathMatch != "" {
+			re, err := regexp.Compile(cfg.Rules[i].PathMatch)
+			if err != nil {
+				return fmt.Errorf("rule %q: invalid path_match: %w", cfg.Rules[i].Name, err)
+			}
+			cfg.Rules[i].compiledPath = re
+		}
+	}
+
+	e.mu.Lock()
+	e.rules = cfg.Rules
+	e.mu.Unlock()
+	return nil
+}
+
+// Rules returns a snapshot of the currently loaded rules.
+func (e *ChaosEngine) Rules() []ChaosRule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	rules := make([]ChaosRule, len(e.rules))
+	copy(rules, e.rules)
+	return rules
+}
+
+// Apply evaluates the rule set against r and runs the action of the first
+// rule that matches and wins its probability roll. It returns true if it
+// wrote a response to w (the caller should stop handling the request), and
+// false if the request should continue normally.
+func (e *ChaosEngine) Apply(w http.ResponseWriter, r *http.Request) bool {
+	for _, rule := range e.Rules() {
+		if rule.Method != "" && rule.Method != r.Method {
+			continue
+		}
+		if rule.compiledPath != nil && !rule.compiledPath.MatchString(r.URL.Path) {
+			continue
+		}
+		if rand.Float64() >= rule.Probability {
+			continue
+		}
+
+		chaosRulesFiredTotal.WithLabelValues(rule.Name, rule.Action.Type).Inc()
+		errorCountTotal.WithLabelValues("chaos_" + rule.Action.Type).Inc()
+
+		logJSON(LogEntry{
+			Level:     "WARN",
+			Message:   fmt.Sprintf("Chaos rule %q fired: %s", rule.Name, rule.Action.Type),
+			ErrorType: "chaos_" + rule.Action.Type,
+		})
+
+		return runChaosAction(w, rule.Action)
+	}
+
+	return false
+}
+
+// runChaosAction executes a single fault action, returning true if it wrote
+// (or intends to terminate, in the panic case) the response.
+func runChaosAction(w http.ResponseWriter, action ChaosAction) bool {
+	switch action.Type {
+	case "panic":
+		panic(fmt.Sprintf("chaos-injected panic (action=%s)", action.Type))
+
+	case "sleep":
+		lo, hi := action.Min, action.Max
+		if hi <= lo {
+			hi = lo
+		}
+		delay := lo
+		if hi > lo {
+			delay = lo + time.Duration(rand.Int63n(int64(hi-lo)))
+		}
+		time.Sleep(delay)
+		return false
+
+	case "abort":
+		status := action.Status
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		w.WriteHeader(status)
+		return true
+
+	case "cpu_burn":
+		deadline := time.Now().Add(action.Duration)
+		for time.Now().Before(deadline) {
+			// Busy-spin to consume CPU for the configured duration.
+		}
+		return false
+
+	case "memory_leak":
+		// Allocate and retain memory for the life of the process to simulate
+		// a leak. Deliberately not freed or garbage-collected away.
+		leaked := make([][]byte, 0, action.MemoryMB)
+		for i := 0; i < action.MemoryMB; i++ {
+			leaked = append(leaked, make([]byte, 1<<20))
+		}
+		chaosLeakedBlocksMu.Lock()
+		chaosLeakedBlocks = append(chaosLeakedBlocks, leaked...)
+		chaosLeakedBlocksMu.Unlock()
+		return false
+
+	case "latency_percentile":
+		delay := action.P50
+		if rand.Float64() < 0.01 {
+			delay = action.P99
+		}
+		time.Sleep(delay)
+		return false
+
+	default:
+		return false
+	}
+}
+
+// chaosLeakedBlocks pins memory_leak allocations so they aren't collected.
+// It's appended to concurrently by runChaosAction across requests, so
+// chaosLeakedBlocksMu guards it.
+var (
+	chaosLeakedBlocksMu sync.Mutex
+	chaosLeakedBlocks   [][]byte
+)
+
+// chaosRulesHandler handles GET/POST /api/v1/chaos/rules: GET returns the
+// currently loaded rule set, POST replaces it with a new YAML document. When
+// cluster is non-nil, the new rule set is committed through Raft so every
+// node in the cluster loads it, instead of just the node that received the
+// request.
+func chaosRulesHandler(engine *ChaosEngine, cluster *Cluster) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID, _ := r.Context().Value("request_id").(string)
+
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"rules": engine.Rules(),
+			})
+
+		case http.MethodPost:
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{
+					Error:     err.Error(),
+					ErrorType: "invalid_request",
+					Timestamp: time.Now().Format(time.RFC3339),
+					RequestID: requestID,
+				})
+				return
+			}
+
+			if cluster != nil {
+				err = cluster.SetChaosRules(body)
+			} else {
+				err = engine.loadYAML(body)
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{
+					Error:     err.Error(),
+					ErrorType: "invalid_chaos_config",
+					Timestamp: time.Now().Format(time.RFC3339),
+					RequestID: requestID,
+				})
+				return
+			}
+
+			logJSON(LogEntry{
+				Level:     "INFO",
+				Message:   fmt.Sprintf("Chaos rules reloaded: %d rules", len(engine.Rules())),
+				RequestID: requestID,
+			})
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"status": "reloaded",
+				"rules":  engine.Rules(),
+			})
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/raft"
+	boltdb "github.com/hashicorp/raft-boltdb"
+)
+
+// clusterCmdType identifies the kind of change committed through Raft.
+type clusterCmdType string
+
+const (
+	clusterCmdFailLiveness  clusterCmdType = "fail_liveness"
+	clusterCmdFailReadiness clusterCmdType = "fail_readiness"
+	clusterCmdSetChaosRules clusterCmdType = "set_chaos_rules"
+)
+
+// clusterCmd is the payload applied to the FSM on every node once a command
+// has been committed by the Raft leader.
+type clusterCmd struct {
+	Type clusterCmdType `json:"type"`
+	At   int64          `json:"at,omitempty"`   // unix timestamp the failure should take effect
+	Data []byte         `json:"data,omitempty"` // raw chaos rules YAML, for clusterCmdSetChaosRules
+}
+
+// clusterFSM applies committed cluster commands to the process-wide health
+// state (livenessFailTime / readinessFailTime) and to the local chaos
+// engine, so every node in the cluster fails together at the same
+// wall-clock instant and runs the same fault rules.
+//
+// isReady is deliberately not replicated: it only ever flips once, at
+// startup, on each node independently, and there is no endpoint that
+// changes it afterwards.
+type clusterFSM struct {
+	chaosEngine *ChaosEngine
+}
+
+func (f *clusterFSM) Apply(log *raft.Log) interface{} {
+	var cmd clusterCmd
+	if err := json.Unmarshal(log.Data, &cmd); err != nil {
+		return fmt.Errorf("failed to unmarshal cluster command: %w", err)
+	}
+
+	switch cmd.Type {
+	case clusterCmdFailLiveness:
+		atomic.StoreInt64(&livenessFailTime, cmd.At)
+	case clusterCmdFailReadiness:
+		atomic.StoreInt64(&readinessFailTime, cmd.At)
+	case clusterCmdSetChaosRules:
+		if f.chaosEngine != nil {
+			return f.chaosEngine.loadYAML(cmd.Data)
+		}
+	}
+
+	return nil
+}
+
+// clusterSnapshot is a no-op FSM snapshot: cluster state is the two failure
+// timestamps plus the chaos rule set, which are cheap to replay from the
+// Raft log on restart rather than snapshotting separately.
+type clusterSnapshot struct{}
+
+func (s *clusterSnapshot) Persist(sink raft.SnapshotSink) error { return sink.Close() }
+func (s *clusterSnapshot) Release()                             {}
+
+func (f *clusterFSM) Snapshot() (raft.FSMSnapshot, error) {
+	return &clusterSnapshot{}, nil
+}
+
+func (f *clusterFSM) Restore(rc io.ReadCloser) error {
+	return rc.Close()
+}
+
+// Cluster wraps a Raft node that replicates chaos rules and the liveness and
+// readiness failure timers across aletheia instances listed in CLUSTER_PEERS.
+// The isReady gate is not replicated; each node sets its own.
+type Cluster struct {
+	raft   *raft.Raft
+	nodeID string
+}
+
+// newCluster starts a Raft node if CLUSTER_PEERS is set, joining the given
+// peer list as the initial voter configuration. It returns nil, nil when
+// clustering is not configured, so callers can treat a nil *Cluster as
+// "running standalone".
+//
+// Every node in the cluster is identified by its routable advertise
+// address, and that same address is used as its raft.ServerID everywhere
+// (by itself, and in every peer's server list) so the whole cluster agrees
+// on one ID->address mapping. CLUSTER_PEERS must list every node's
+// advertise address, including this node's own.
+func newCluster(chaosEngine *ChaosEngine) (*Cluster, error) {
+	peersEnv := os.Getenv("CLUSTER_PEERS")
+	if peersEnv == "" {
+		return nil, nil
+	}
+
+	bindAddr := os.Getenv("CLUSTER_BIND_ADDR")
+	if bindAddr == "" {
+		bindAddr = "0.0.0.0:7946"
+	}
+
+	advertiseAddr := os.Getenv("CLUSTER_ADVERTISE_ADDR")
+	if advertiseAddr == "" {
+		advertiseAddr = bindAddr
+	}
+	if host, _, err := net.SplitHostPort(advertiseAddr); err != nil || host == "" || host == "0.0.0.0" {
+		return nil, fmt.Errorf("CLUSTER_ADVERTISE_ADDR must be set to a routable host:port (got %q)", advertiseAddr)
+	}
+
+	nodeID := advertiseAddr
+
+	dataDir := os.Getenv("CLUSTER_DATA_DIR")
+	if dataDir == "" {
+		dataDir = "/tmp/aletheia-raft"
+	}
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create raft data dir: %w", err)
+	}
+
+	config := raft.DefaultConfig()
+	config.LocalID = raft.ServerID(nodeID)
+
+	advertiseTCPAddr, err := net.ResolveTCPAddr("tcp", advertiseAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve raft advertise addr: %w", err)
+	}
+	transport, err := raft.NewTCPTransport(bindAddr, advertiseTCPAddr, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create raft transport: %w", err)
+	}
+
+	logStore, err := boltdb.NewBoltStore(filepath.Join(dataDir, "raft-log.db"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create raft log store: %w", err)
+	}
+	stableStore, err := boltdb.NewBoltStore(filepath.Join(dataDir, "raft-stable.db"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create raft stable store: %w", err)
+	}
+	snapshotStore, err := raft.NewFileSnapshotStore(dataDir, 2, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create raft snapshot store: %w", err)
+	}
+
+	fsm := &clusterFSM{chaosEngine: chaosEngine}
+	r, err := raft.NewRaft(config, fsm, logStore, stableStore, snapshotStore, transport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create raft node: %w", err)
+	}
+
+	hasState, err := raft.HasExistingState(logStore, stableStore, snapshotStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect raft state: %w", err)
+	}
+
+	// Only one node should bootstrap the cluster's initial configuration;
+	// every other node joins by being listed in that configuration. Restarts
+	// never re-bootstrap, since hasState will be true from then on.
+	if !hasState && os.Getenv("CLUSTER_BOOTSTRAP") == "true" {
+		servers := []raft.Server{{ID: config.LocalID, Address: raft.ServerAddress(advertiseAddr)}}
+		for _, peer := range strings.Split(peersEnv, ",") {
+			peer = strings.TrimSpace(peer)
+			if peer == "" || peer == advertiseAddr {
+				continue
+			}
+			servers = append(servers, raft.Server{ID: raft.ServerID(peer), Address: raft.ServerAddress(peer)})
+		}
+		if err := r.BootstrapCluster(raft.Configuration{Servers: servers}).Error(); err != nil {
+			return nil, fmt.Errorf("failed to bootstrap raft cluster: %w", err)
+		}
+	}
+
+	return &Cluster{raft: r, nodeID: nodeID}, nil
+}
+
+// FailLivenessAt commits a liveness failure timestamp through Raft so every
+// node in the cluster applies it at the same wall-clock instant.
+func (c *Cluster) FailLivenessAt(at time.Time) error {
+	return c.propose(clusterCmd{Type: clusterCmdFailLiveness, At: at.Unix()})
+}
+
+// FailReadinessAt commits a readiness failure timestamp through Raft so
+// every node in the cluster applies it at the same wall-clock instant.
+func (c *Cluster) FailReadinessAt(at time.Time) error {
+	return c.propose(clusterCmd{Type: clusterCmdFailReadiness, At: at.Unix()})
+}
+
+// SetChaosRules commits a new chaos rules YAML document through Raft so
+// every node in the cluster loads the same rule set.
+func (c *Cluster) SetChaosRules(yamlData []byte) error {
+	return c.propose(clusterCmd{Type: clusterCmdSetChaosRules, Data: yamlData})
+}
+
+func (c *Cluster) propose(cmd clusterCmd) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cluster command: %w", err)
+	}
+
+	future := c.raft.Apply(data, 5*time.Second)
+	if err := future.Error(); err != nil {
+		return fmt.Errorf("failed to commit cluster command: %w", err)
+	}
+	if err, ok := future.Response().(error); ok && err != nil {
+		return err
+	}
+	return nil
+}
+
+// clusterStatusHandler handles GET /api/v1/cluster/status, reporting leader
+// ID, term, and peer states.
+func clusterStatusHandler(cluster *Cluster) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if cluster == nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"clustered": false,
+			})
+			return
+		}
+
+		leaderAddr, leaderID := cluster.raft.LeaderWithID()
+		cfgFuture := cluster.raft.GetConfiguration()
+
+		resp := map[string]interface{}{
+			"clustered":   true,
+			"node_id":     cluster.nodeID,
+			"state":       cluster.raft.State().String(),
+			"term":        cluster.raft.Stats()["term"],
+			"leader_id":   string(leaderID),
+			"leader_addr": string(leaderAddr),
+		}
+		if err := cfgFuture.Error(); err == nil {
+			resp["peers"] = cfgFuture.Configuration().Servers
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// clusterFailLivenessHandler handles POST /api/v1/cluster/fail-liveness?after=30s,
+// committing the failure timestamp through Raft.
+func clusterFailLivenessHandler(cluster *Cluster) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID, _ := r.Context().Value("request_id").(string)
+
+		if cluster == nil {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error:     "clustering is not enabled (set CLUSTER_PEERS)",
+				ErrorType: "not_clustered",
+				Timestamp: time.Now().Format(time.RFC3339),
+				RequestID: requestID,
+			})
+			return
+		}
+
+		after := r.URL.Query().Get("after")
+		duration, err := time.ParseDuration(after)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error:     fmt.Sprintf("invalid after duration: %v", err),
+				ErrorType: "invalid_request",
+				Timestamp: time.Now().Format(time.RFC3339),
+				RequestID: requestID,
+			})
+			return
+		}
+
+		if err := cluster.FailLivenessAt(time.Now().Add(duration)); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error:     err.Error(),
+				ErrorType: "raft_apply_failed",
+				Timestamp: time.Now().Format(time.RFC3339),
+				RequestID: requestID,
+			})
+			return
+		}
+
+		logJSON(LogEntry{
+			Level:     "WARN",
+			Message:   fmt.Sprintf("Cluster-wide liveness failure committed, effective in %s", duration),
+			RequestID: requestID,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "committed",
+			"after":  duration.String(),
+		})
+	}
+}
+
+// clusterFailReadinessHandler handles POST /api/v1/cluster/fail-readiness?after=30s,
+// committing the failure timestamp through Raft.
+func clusterFailReadinessHandler(cluster *Cluster) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID, _ := r.Context().Value("request_id").(string)
+
+		if cluster == nil {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error:     "clustering is not enabled (set CLUSTER_PEERS)",
+				ErrorType: "not_clustered",
+				Timestamp: time.Now().Format(time.RFC3339),
+				RequestID: requestID,
+			})
+			return
+		}
+
+		after := r.URL.Query().Get("after")
+		duration, err := time.ParseDuration(after)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error:     fmt.Sprintf("invalid after duration: %v", err),
+				ErrorType: "invalid_request",
+				Timestamp: time.Now().Format(time.RFC3339),
+				RequestID: requestID,
+			})
+			return
+		}
+
+		if err := cluster.FailReadinessAt(time.Now().Add(duration)); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{
+				Error:     err.Error(),
+				ErrorType: "raft_apply_failed",
+				Timestamp: time.Now().Format(time.RFC3339),
+				RequestID: requestID,
+			})
+			return
+		}
+
+		logJSON(LogEntry{
+			Level:     "WARN",
+			Message:   fmt.Sprintf("Cluster-wide readiness failure committed, effective in %s", duration),
+			RequestID: requestID,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "committed",
+			"after":  duration.String(),
+		})
+	}
+}
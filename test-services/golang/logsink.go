@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	logSinkBytesShippedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "log_sink_bytes_shipped_total",
+			Help: "Total bytes shipped by each log sink",
+		},
+		[]string{"sink"},
+	)
+
+	logSinkDroppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "log_sink_dropped_total",
+			Help: "Total log entries dropped by each log sink",
+		},
+		[]string{"sink"},
+	)
+
+	logSinkQueueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "log_sink_queue_depth",
+			Help: "Current queue depth of each log sink",
+		},
+		[]string{"sink"},
+	)
+)
+
+// LogSink receives every LogEntry emitted by logJSON. Implementations must
+// be safe for concurrent use.
+type LogSink interface {
+	Name() string
+	Write(entry LogEntry)
+}
+
+// LogSinkConfig is the top-level YAML document describing which sinks to
+// fan log entries out to.
+type LogSinkConfig struct {
+	Sinks struct {
+		Stdout *struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"stdout"`
+		HTTP *struct {
+			URL        string        `yaml:"url"`
+			SpoolDir   string        `yaml:"spool_dir"`
+			BatchSize  int           `yaml:"batch_size"`
+			FlushEvery time.Duration `yaml:"flush_every"`
+		} `yaml:"http"`
+		Syslog *struct {
+			Network string `yaml:"network"` // "udp" or "tcp"
+			Address string `yaml:"address"`
+		} `yaml:"syslog"`
+		Kafka *struct {
+			Brokers []string `yaml:"brokers"`
+			Topic   string   `yaml:"topic"`
+		} `yaml:"kafka"`
+	} `yaml:"sinks"`
+}
+
+// fanoutSink dispatches every entry to each of its sinks.
+type fanoutSink struct {
+	sinks []LogSink
+}
+
+func (f *fanoutSink) Name() string { return "fanout" }
+
+func (f *fanoutSink) Write(entry LogEntry) {
+	for _, s := range f.sinks {
+		s.Write(entry)
+	}
+}
+
+// newLogSinks builds the configured fan-out of log sinks from LOG_SINK_CONFIG
+// (a YAML file) plus LOG_SINK_URL (a shorthand for the HTTP sink's collector
+// URL, used when the YAML doesn't set sinks.http.url), falling back to
+// stdout-only when neither is set.
+func newLogSinks() *fanoutSink {
+	stdout := &stdoutSink{}
+
+	var cfg LogSinkConfig
+	if path := os.Getenv("LOG_SINK_CONFIG"); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			yaml.Unmarshal(data, &cfg)
+		}
+	}
+
+	var sinks []LogSink
+	if cfg.Sinks.Stdout == nil || cfg.Sinks.Stdout.Enabled {
+		sinks = append(sinks, stdout)
+	}
+
+	httpURL := os.Getenv("LOG_SINK_URL")
+	var spoolDir string
+	var batchSize int
+	var flushEvery time.Duration
+	if h := cfg.Sinks.HTTP; h != nil {
+		if h.URL != "" {
+			httpURL = h.URL
+		}
+		spoolDir, batchSize, flushEvery = h.SpoolDir, h.BatchSize, h.FlushEvery
+	}
+	if httpURL != "" {
+		sinks = append(sinks, newHTTPSink(httpURL, spoolDir, batchSize, flushEvery))
+	}
+
+	if sl := cfg.Sinks.Syslog; sl != nil && sl.Address != "" {
+		sinks = append(sinks, newSyslogSink(sl.Network, sl.Address))
+	}
+	if k := cfg.Sinks.Kafka; k != nil && k.Topic != "" {
+		sinks = append(sinks, newKafkaSink(k.Brokers, k.Topic))
+	}
+
+	if len(sinks) == 0 {
+		sinks = []LogSink{stdout}
+	}
+
+	return &fanoutSink{sinks: sinks}
+}
+
+// stdoutSink is the default sink: one JSON line per entry on stdout.
+type stdoutSink struct{}
+
+func (s *stdoutSink) Name() string { return "stdout" }
+
+func (s *stdoutSink) Write(entry LogEntry) {
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		logSinkDroppedTotal.WithLabelValues(s.Name()).Inc()
+		return
+	}
+	fmt.Println(string(jsonBytes))
+	logSinkBytesShippedTotal.WithLabelValues(s.Name()).Add(float64(len(jsonBytes) + 1))
+}
+
+// httpSink batches entries and POSTs them as newline-delimited JSON to a
+// collector URL, spooling to disk when the collector is unreachable.
+type httpSink struct {
+	url        string
+	spoolDir   string
+	batchSize  int
+	flushEvery time.Duration
+
+	mu      sync.Mutex
+	batch   []LogEntry
+	client  *http.Client
+	spoolMu sync.Mutex
+}
+
+func newHTTPSink(url, spoolDir string, batchSize int, flushEvery time.Duration) *httpSink {
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+	if flushEvery <= 0 {
+		flushEvery = 5 * time.Second
+	}
+	if spoolDir == "" {
+		spoolDir = "/tmp/aletheia-log-spool"
+	}
+	os.MkdirAll(spoolDir, 0o755)
+
+	s := &httpSink{
+		url:        url,
+		spoolDir:   spoolDir,
+		batchSize:  batchSize,
+		flushEvery: flushEvery,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+
+	go s.flushLoop()
+	go s.replayLoop()
+	return s
+}
+
+func (s *httpSink) Name() string { return "http" }
+
+func (s *httpSink) Write(entry LogEntry) {
+	s.mu.Lock()
+	s.batch = append(s.batch, entry)
+	full := len(s.batch) >= s.batchSize
+	logSinkQueueDepth.WithLabelValues(s.Name()).Set(float64(len(s.batch)))
+	s.mu.Unlock()
+
+	if full {
+		s.flush()
+	}
+}
+
+func (s *httpSink) flushLoop() {
+	ticker := time.NewTicker(s.flushEvery)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.flush()
+	}
+}
+
+func (s *httpSink) flush() {
+	s.mu.Lock()
+	batch := s.batch
+	s.batch = nil
+	logSinkQueueDepth.WithLabelValues(s.Name()).Set(0)
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range batch {
+		jsonBytes, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		buf.Write(jsonBytes)
+		buf.WriteByte('\n')
+	}
+
+	if err := s.ship(buf.Bytes()); err != nil {
+		if spoolErr := s.spool(buf.Bytes()); spoolErr != nil {
+			logSinkDroppedTotal.WithLabelValues(s.Name()).Add(float64(len(batch)))
+		}
+		return
+	}
+
+	logSinkBytesShippedTotal.WithLabelValues(s.Name()).Add(float64(buf.Len()))
+}
+
+func (s *httpSink) ship(payload []byte) error {
+	resp, err := s.client.Post(s.url, "application/x-ndjson", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("log collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// spool writes an unshippable batch to disk so it can be replayed once the
+// collector is back.
+func (s *httpSink) spool(payload []byte) error {
+	s.spoolMu.Lock()
+	defer s.spoolMu.Unlock()
+
+	f, err := os.CreateTemp(s.spoolDir, "batch-*.ndjson")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(payload)
+	return err
+}
+
+// replayLoop periodically drains spoolDir, re-shipping each spooled batch
+// and deleting it once the collector accepts it, so a dead collector
+// doesn't lose logs permanently.
+func (s *httpSink) replayLoop() {
+	ticker := time.NewTicker(s.flushEvery)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.replaySpool()
+	}
+}
+
+func (s *httpSink) replaySpool() {
+	s.spoolMu.Lock()
+	defer s.spoolMu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(s.spoolDir, "batch-*.ndjson"))
+	if err != nil {
+		return
+	}
+
+	for _, path := range files {
+		payload, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		if err := s.ship(payload); err != nil {
+			// Still down; leave the file for the next replay pass.
+			continue
+		}
+
+		os.Remove(path)
+		logSinkBytesShippedTotal.WithLabelValues(s.Name()).Add(float64(len(payload)))
+	}
+}
+
+// syslogSink writes each entry as a syslog-style line over UDP or TCP. Writes
+// happen on a background writeLoop fed by a buffered channel, so a slow or
+// down syslog receiver (a blocking reconnect dial, or a stalled TCP write)
+// never blocks the request path that called Write.
+type syslogSink struct {
+	network string
+	address string
+
+	mu   sync.Mutex
+	conn net.Conn
+
+	entries chan LogEntry
+}
+
+func newSyslogSink(network, address string) *syslogSink {
+	if network == "" {
+		network = "udp"
+	}
+	s := &syslogSink{network: network, address: address, entries: make(chan LogEntry, 1024)}
+	s.dial()
+	go s.writeLoop()
+	return s
+}
+
+func (s *syslogSink) Name() string { return "syslog" }
+
+func (s *syslogSink) dial() {
+	conn, err := net.Dial(s.network, s.address)
+	if err == nil {
+		s.conn = conn
+	}
+}
+
+// Write enqueues entry for writeLoop instead of dialing/writing inline.
+func (s *syslogSink) Write(entry LogEntry) {
+	select {
+	case s.entries <- entry:
+	default:
+		logSinkDroppedTotal.WithLabelValues(s.Name()).Inc()
+	}
+}
+
+func (s *syslogSink) writeLoop() {
+	for entry := range s.entries {
+		s.writeOne(entry)
+	}
+}
+
+func (s *syslogSink) writeOne(entry LogEntry) {
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		logSinkDroppedTotal.WithLabelValues(s.Name()).Inc()
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		s.dial()
+		if s.conn == nil {
+			logSinkDroppedTotal.WithLabelValues(s.Name()).Inc()
+			return
+		}
+	}
+
+	line := fmt.Sprintf("<%d>%s aletheia: %s\n", syslogPriority(entry.Level), time.Now().Format(time.RFC3339), jsonBytes)
+	if _, err := s.conn.Write([]byte(line)); err != nil {
+		logSinkDroppedTotal.WithLabelValues(s.Name()).Inc()
+		s.conn = nil
+		return
+	}
+	logSinkBytesShippedTotal.WithLabelValues(s.Name()).Add(float64(len(line)))
+}
+
+// syslogPriority maps our LogEntry levels onto RFC 5424 severities (facility 1, user-level).
+func syslogPriority(level string) int {
+	const facility = 1 << 3
+	switch level {
+	case "FATAL":
+		return facility | 2
+	case "ERROR":
+		return facility | 3
+	case "WARN":
+		return facility | 4
+	default:
+		return facility | 6
+	}
+}
+
+// kafkaSink produces each entry as a JSON message to a Kafka topic using a
+// real Kafka client, so it speaks the actual wire protocol instead of a
+// made-up framing no broker understands. The writer runs in Async mode so
+// WriteMessages only enqueues onto kafka-go's internal batch queue and
+// returns immediately; Completion reports the actual delivery outcome once
+// a batch goes out, instead of Write blocking the request path on it.
+type kafkaSink struct {
+	topic  string
+	writer *kafka.Writer
+}
+
+func newKafkaSink(brokers []string, topic string) *kafkaSink {
+	s := &kafkaSink{topic: topic}
+	s.writer = &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 1 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				logSinkDroppedTotal.WithLabelValues(s.Name()).Add(float64(len(messages)))
+				return
+			}
+			var shipped int
+			for _, m := range messages {
+				shipped += len(m.Value)
+			}
+			logSinkBytesShippedTotal.WithLabelValues(s.Name()).Add(float64(shipped))
+		},
+	}
+	return s
+}
+
+func (s *kafkaSink) Name() string { return "kafka" }
+
+func (s *kafkaSink) Write(entry LogEntry) {
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		logSinkDroppedTotal.WithLabelValues(s.Name()).Inc()
+		return
+	}
+
+	if err := s.writer.WriteMessages(context.Background(), kafka.Message{Value: jsonBytes}); err != nil {
+		logSinkDroppedTotal.WithLabelValues(s.Name()).Inc()
+	}
+}
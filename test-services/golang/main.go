@@ -19,6 +19,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -64,28 +67,42 @@ var (
 
 // LogEntry represents a structured log entry
 type LogEntry struct {
-	Timestamp   string                 `json:"timestamp"`
-	Level       string                 `json:"level"`
-	Message     string                 `json:"message"`
-	RequestID   string                 `json:"request_id,omitempty"`
-	ClientIP    string                 `json:"client_ip,omitempty"`
-	Method      string                 `json:"method,omitempty"`
-	Path        string                 `json:"path,omitempty"`
-	Status      int                    `json:"status,omitempty"`
-	Duration    int64                  `json:"duration_ms,omitempty"`
-	Error       string                 `json:"error,omitempty"`
-	ErrorType   string                 `json:"error_type,omitempty"`
-	StackTrace  string                 `json:"stack_trace,omitempty"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Timestamp  string                 `json:"timestamp"`
+	Level      string                 `json:"level"`
+	Message    string                 `json:"message"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	ClientIP   string                 `json:"client_ip,omitempty"`
+	Method     string                 `json:"method,omitempty"`
+	Path       string                 `json:"path,omitempty"`
+	Status     int                    `json:"status,omitempty"`
+	Duration   int64                  `json:"duration_ms,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	ErrorType  string                 `json:"error_type,omitempty"`
+	StackTrace string                 `json:"stack_trace,omitempty"`
+	TraceID    string                 `json:"trace_id,omitempty"`
+	SpanID     string                 `json:"span_id,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// logJSON logs a structured JSON entry to stdout
+// traceFields pulls the trace_id/span_id of the active span out of ctx, if any.
+func traceFields(ctx context.Context) (traceID, spanID string) {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return "", ""
+	}
+	return spanCtx.TraceID().String(), spanCtx.SpanID().String()
+}
+
+// activeLogSink is where logJSON dispatches every entry. It defaults to
+// stdout-only and is replaced in main() once LOG_SINK_CONFIG is parsed.
+var activeLogSink LogSink = &stdoutSink{}
+
+// logJSON dispatches a structured log entry to the configured log sink(s).
 func logJSON(entry LogEntry) {
 	if entry.Timestamp == "" {
 		entry.Timestamp = time.Now().Format(time.RFC3339)
 	}
-	jsonBytes, _ := json.Marshal(entry)
-	fmt.Println(string(jsonBytes))
+	activeLogSink.Write(entry)
 }
 
 // getStackTrace captures the current stack trace
@@ -116,6 +133,8 @@ func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Create a response writer wrapper to capture status code
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: 200}
 
+		traceID, spanID := traceFields(r.Context())
+
 		// Log request
 		logJSON(LogEntry{
 			Level:     "INFO",
@@ -124,6 +143,8 @@ func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			ClientIP:  r.RemoteAddr,
 			Method:    r.Method,
 			Path:      r.URL.Path,
+			TraceID:   traceID,
+			SpanID:    spanID,
 		})
 
 		// Handle the request with panic recovery
@@ -136,6 +157,10 @@ func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 					stackTrace := getStackTrace()
 					errorMsg := fmt.Sprintf("%v", rec)
 
+					span := trace.SpanFromContext(r.Context())
+					span.RecordError(fmt.Errorf("%s", errorMsg))
+					span.SetStatus(codes.Error, errorMsg)
+
 					logJSON(LogEntry{
 						Level:      "FATAL",
 						Message:    "Panic recovered",
@@ -143,6 +168,8 @@ func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 						Error:      errorMsg,
 						ErrorType:  "panic",
 						StackTrace: stackTrace,
+						TraceID:    traceID,
+						SpanID:     spanID,
 					})
 
 					wrapped.statusCode = http.StatusInternalServerError
@@ -169,6 +196,8 @@ func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			Path:      r.URL.Path,
 			Status:    wrapped.statusCode,
 			Duration:  duration,
+			TraceID:   traceID,
+			SpanID:    spanID,
 		}
 
 		if wrapped.statusCode >= 400 {
@@ -194,96 +223,16 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// errorHandler handles the /api/v1/error endpoint
-func errorHandler(w http.ResponseWriter, r *http.Request) {
-	requestID := r.Context().Value("request_id").(string)
-	errorType := r.URL.Query().Get("type")
-
-	if errorType == "" {
-		errorType = "nil_pointer"
-	}
-
-	logJSON(LogEntry{
-		Level:     "WARN",
-		Message:   fmt.Sprintf("Triggering intentional error: %s", errorType),
-		RequestID: requestID,
-		ErrorType: errorType,
-	})
-
-	errorCountTotal.WithLabelValues(errorType).Inc()
-
-	switch errorType {
-	case "nil_pointer":
-		var ptr *string
-		_ = *ptr // This will panic with nil pointer dereference
-
-	case "index_out_of_bounds":
-		arr := []int{1, 2, 3}
-		_ = arr[10] // This will panic with index out of bounds
-
-	case "divide_by_zero":
-		x := 42
-		y := 0
-		_ = x / y // This will panic with integer divide by zero
-
-	case "json_unmarshal":
-		var data map[string]interface{}
-		invalidJSON := `{"broken": json}`
-		err := json.Unmarshal([]byte(invalidJSON), &data)
-		if err != nil {
-			logJSON(LogEntry{
-				Level:      "ERROR",
-				Message:    "JSON unmarshal error",
-				RequestID:  requestID,
-				Error:      err.Error(),
-				ErrorType:  "json_unmarshal",
-				StackTrace: getStackTrace(),
-			})
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(ErrorResponse{
-				Error:     err.Error(),
-				ErrorType: "json_unmarshal",
-				Timestamp: time.Now().Format(time.RFC3339),
-				RequestID: requestID,
-			})
+// chaosMiddleware wraps next with a chaos-injection check: if a fired rule
+// writes the response (abort), the request is short-circuited there, and a
+// "panic" action propagates up into loggingMiddleware's recovery handler.
+// The other actions delay or load the request and then let next run.
+func chaosMiddleware(engine *ChaosEngine, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if engine.Apply(w, r) {
 			return
 		}
-
-	case "db_timeout":
-		logJSON(LogEntry{
-			Level:     "ERROR",
-			Message:   "Simulated database connection timeout",
-			RequestID: requestID,
-			Error:     "connection timeout after 30s",
-			ErrorType: "db_timeout",
-			Metadata: map[string]interface{}{
-				"database": "postgres",
-				"timeout":  "30s",
-				"query":    "SELECT * FROM users WHERE id = ?",
-			},
-		})
-		time.Sleep(100 * time.Millisecond) // Simulate delay
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(ErrorResponse{
-			Error:     "database connection timeout",
-			ErrorType: "db_timeout",
-			Timestamp: time.Now().Format(time.RFC3339),
-			RequestID: requestID,
-		})
-		return
-
-	default:
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{
-			Error:     fmt.Sprintf("unknown error type: %s", errorType),
-			ErrorType: "invalid_request",
-			Timestamp: time.Now().Format(time.RFC3339),
-			RequestID: requestID,
-		})
-		return
+		next(w, r)
 	}
 }
 
@@ -376,14 +325,19 @@ func readyzHandler(w http.ResponseWriter, r *http.Request) {
 func rootHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"service":  "aletheia-golang-test-service",
-		"version":  "1.0.0",
-		"uptime":   time.Since(startTime).String(),
-		"ready":    isReady.Load(),
+		"service": "aletheia-golang-test-service",
+		"version": "1.0.0",
+		"uptime":  time.Since(startTime).String(),
+		"ready":   isReady.Load(),
 		"endpoints": []string{
 			"GET /",
-			"GET /api/v1/error?type={nil_pointer|index_out_of_bounds|divide_by_zero|json_unmarshal|db_timeout}",
 			"GET /api/v1/random",
+			"GET /api/v1/chaos/rules",
+			"POST /api/v1/chaos/rules",
+			"GET /api/v1/cluster/status",
+			"POST /api/v1/cluster/fail-liveness?after=30s",
+			"POST /api/v1/cluster/fail-readiness?after=30s",
+			"GET /api/v1/stream (WebSocket)",
 			"GET /healthz",
 			"GET /readyz",
 			"GET /metrics",
@@ -398,6 +352,11 @@ func main() {
 	}))
 	slog.SetDefault(logger)
 
+	// Configure the log-sink fan-out (stdout, HTTP shipper, syslog, Kafka),
+	// plus the live WebSocket stream hub as an additional sink
+	streamHub := NewStreamHub()
+	activeLogSink = &fanoutSink{sinks: []LogSink{newLogSinks(), streamHub}}
+
 	// Parse configuration from environment
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -446,14 +405,49 @@ func main() {
 	// Mark service as ready
 	isReady.Store(true)
 
+	// Initialize OpenTelemetry tracing
+	tp, err := initTracer(context.Background())
+	if err != nil {
+		logJSON(LogEntry{
+			Level:   "WARN",
+			Message: "Failed to initialize tracing",
+			Error:   err.Error(),
+		})
+	}
+
+	// Configure the chaos-injection engine
+	chaosEngine := newChaosEngine()
+
+	// Join or start a Raft cluster if CLUSTER_PEERS is configured
+	cluster, err := newCluster(chaosEngine)
+	if err != nil {
+		logJSON(LogEntry{
+			Level:   "ERROR",
+			Message: "Failed to start cluster",
+			Error:   err.Error(),
+		})
+	}
+
 	// Set up HTTP routes
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", loggingMiddleware(rootHandler))
-	mux.HandleFunc("/api/v1/error", loggingMiddleware(errorHandler))
-	mux.HandleFunc("/api/v1/random", loggingMiddleware(randomHandler))
+	mux.HandleFunc("/", loggingMiddleware(chaosMiddleware(chaosEngine, rootHandler)))
+	mux.HandleFunc("/api/v1/random", loggingMiddleware(chaosMiddleware(chaosEngine, randomHandler)))
+	mux.HandleFunc("/api/v1/chaos/rules", loggingMiddleware(chaosRulesHandler(chaosEngine, cluster)))
+	mux.HandleFunc("/api/v1/cluster/status", loggingMiddleware(clusterStatusHandler(cluster)))
+	mux.HandleFunc("/api/v1/cluster/fail-liveness", loggingMiddleware(clusterFailLivenessHandler(cluster)))
+	mux.HandleFunc("/api/v1/cluster/fail-readiness", loggingMiddleware(clusterFailReadinessHandler(cluster)))
 	mux.HandleFunc("/healthz", loggingMiddleware(healthzHandler))
 	mux.HandleFunc("/readyz", loggingMiddleware(readyzHandler))
 
+	tracedMux := otelhttp.NewHandler(mux, "aletheia-golang-test-service")
+
+	// /api/v1/stream is registered outside the otelhttp wrapper: otelhttp's
+	// wrapped ResponseWriter doesn't reliably implement http.Hijacker, which
+	// gorilla/websocket's Upgrade requires to take over the connection.
+	topMux := http.NewServeMux()
+	topMux.HandleFunc("/api/v1/stream", streamHub.Handler())
+	topMux.Handle("/", tracedMux)
+
 	// Set up metrics server
 	metricsMux := http.NewServeMux()
 	metricsMux.Handle("/metrics", promhttp.Handler())
@@ -461,7 +455,7 @@ func main() {
 	// Start main HTTP server
 	server := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: topMux,
 	}
 
 	metricsServer := &http.Server{
@@ -539,6 +533,16 @@ func main() {
 		})
 	}
 
+	if tp != nil {
+		if err := tp.Shutdown(ctx); err != nil {
+			logJSON(LogEntry{
+				Level:   "ERROR",
+				Message: "TracerProvider shutdown error",
+				Error:   err.Error(),
+			})
+		}
+	}
+
 	logJSON(LogEntry{
 		Level:   "INFO",
 		Message: "Service stopped",
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	streamClientsGauge = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "stream_clients_connected",
+			Help: "Current number of connected /api/v1/stream WebSocket clients",
+		},
+	)
+
+	streamFramesDroppedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "stream_frames_dropped_total",
+			Help: "Total number of stream frames dropped due to backpressure",
+		},
+	)
+)
+
+// streamFrame is the envelope sent to every WebSocket subscriber. Chaos rule
+// firings are not a distinct frame type: they already arrive as "log" frames
+// with ErrorType "chaos_<action>", since logJSON fans every entry out to the
+// StreamHub alongside the other log sinks.
+type streamFrame struct {
+	Type      string                 `json:"type"` // log, metrics
+	Timestamp string                 `json:"timestamp"`
+	Log       *LogEntry              `json:"log,omitempty"`
+	Metrics   map[string]interface{} `json:"metrics,omitempty"`
+}
+
+// streamFilter is the client-provided subscribe message that narrows which
+// log frames it receives.
+type streamFilter struct {
+	Level      string `json:"level,omitempty"`
+	PathPrefix string `json:"path_prefix,omitempty"`
+}
+
+type streamSubscribeMessage struct {
+	Filter streamFilter `json:"filter"`
+}
+
+// streamClient is one connected WebSocket subscriber with its own
+// backpressure-bounded send buffer.
+type streamClient struct {
+	conn   *websocket.Conn
+	send   chan streamFrame
+	mu     sync.Mutex
+	filter streamFilter
+}
+
+func (c *streamClient) matches(entry LogEntry) bool {
+	c.mu.Lock()
+	filter := c.filter
+	c.mu.Unlock()
+
+	if filter.Level != "" && filter.Level != entry.Level {
+		return false
+	}
+	if filter.PathPrefix != "" && !strings.HasPrefix(entry.Path, filter.PathPrefix) {
+		return false
+	}
+	return true
+}
+
+// StreamHub fans out LogEntry frames (and periodic metrics snapshots) to
+// every connected /api/v1/stream client, and is itself a LogSink so it
+// plugs into the same fan-out as the other log sinks.
+type StreamHub struct {
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	clients map[*streamClient]struct{}
+}
+
+// NewStreamHub creates an empty hub and starts its periodic metrics
+// broadcaster.
+func NewStreamHub() *StreamHub {
+	h := &StreamHub{
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     func(r *http.Request) bool { return true },
+		},
+		clients: make(map[*streamClient]struct{}),
+	}
+	go h.broadcastMetricsLoop()
+	return h
+}
+
+func (h *StreamHub) Name() string { return "stream" }
+
+// Write implements LogSink: broadcast the entry as a "log" frame to every
+// subscriber whose filter matches.
+func (h *StreamHub) Write(entry LogEntry) {
+	h.broadcast(streamFrame{
+		Type:      "log",
+		Timestamp: time.Now().Format(time.RFC3339),
+		Log:       &entry,
+	}, func(c *streamClient) bool { return c.matches(entry) })
+}
+
+func (h *StreamHub) broadcastMetricsLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.mu.Lock()
+		clientCount := len(h.clients)
+		h.mu.Unlock()
+
+		metrics := gatherAppMetrics()
+		metrics["connected_clients"] = clientCount
+		metrics["uptime_seconds"] = time.Since(startTime).Seconds()
+
+		h.broadcast(streamFrame{
+			Type:      "metrics",
+			Timestamp: time.Now().Format(time.RFC3339),
+			Metrics:   metrics,
+		}, nil)
+	}
+}
+
+// gatherAppMetrics snapshots every promauto-registered counter and gauge in
+// this process (the Go runtime/process collectors are skipped; they're
+// already scraped separately via /metrics) into a flat name->value map
+// suitable for a "metrics" stream frame.
+func gatherAppMetrics() map[string]interface{} {
+	metrics := make(map[string]interface{})
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return metrics
+	}
+
+	for _, mf := range families {
+		name := mf.GetName()
+		if strings.HasPrefix(name, "go_") || strings.HasPrefix(name, "process_") {
+			continue
+		}
+		if t := mf.GetType(); t != dto.MetricType_COUNTER && t != dto.MetricType_GAUGE {
+			continue
+		}
+		metrics[name] = sumMetricFamily(mf)
+	}
+
+	return metrics
+}
+
+// sumMetricFamily collapses every label combination of a counter or gauge
+// family into a single total, since the stream frame reports headline
+// numbers rather than a full label breakdown.
+func sumMetricFamily(mf *dto.MetricFamily) float64 {
+	var total float64
+	for _, m := range mf.GetMetric() {
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			total += m.GetCounter().GetValue()
+		case dto.MetricType_GAUGE:
+			total += m.GetGauge().GetValue()
+		}
+	}
+	return total
+}
+
+// broadcast sends frame to every client for which match is nil or returns
+// true, dropping the frame for any client whose send buffer is full.
+func (h *StreamHub) broadcast(frame streamFrame, match func(*streamClient) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.clients {
+		if match != nil && !match(c) {
+			continue
+		}
+		select {
+		case c.send <- frame:
+		default:
+			streamFramesDroppedTotal.Inc()
+		}
+	}
+}
+
+// Handler upgrades GET /api/v1/stream to a WebSocket and streams frames to
+// the client until it disconnects.
+func (h *StreamHub) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := h.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logJSON(LogEntry{
+				Level:   "WARN",
+				Message: "Failed to upgrade stream client",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		client := &streamClient{
+			conn: conn,
+			send: make(chan streamFrame, 64),
+		}
+
+		h.mu.Lock()
+		h.clients[client] = struct{}{}
+		h.mu.Unlock()
+		streamClientsGauge.Inc()
+
+		go h.readLoop(client)
+		h.writeLoop(client)
+	}
+}
+
+// readLoop handles subscribe messages from the client until it disconnects,
+// then removes it from the hub.
+func (h *StreamHub) readLoop(c *streamClient) {
+	defer h.remove(c)
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var sub streamSubscribeMessage
+		if err := json.Unmarshal(data, &sub); err != nil {
+			continue
+		}
+
+		c.mu.Lock()
+		c.filter = sub.Filter
+		c.mu.Unlock()
+	}
+}
+
+// writeLoop drains c.send to the WebSocket connection until the connection
+// is closed by readLoop.
+func (h *StreamHub) writeLoop(c *streamClient) {
+	for frame := range c.send {
+		if err := c.conn.WriteJSON(frame); err != nil {
+			return
+		}
+	}
+}
+
+// remove closes the client's connection and stops its writeLoop.
+func (h *StreamHub) remove(c *streamClient) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+
+	close(c.send)
+	c.conn.Close()
+	streamClientsGauge.Dec()
+}
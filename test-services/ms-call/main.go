@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/yaml.v3"
 )
 
@@ -23,8 +25,9 @@ type Config struct {
 		Port string `yaml:"port"`
 	} `yaml:"server"`
 	Downstream struct {
-		URL     string        `yaml:"url"`
-		Timeout time.Duration `yaml:"timeout"`
+		URL        string           `yaml:"url"`
+		Timeout    time.Duration    `yaml:"timeout"`
+		Resilience ResilienceConfig `yaml:"resilience"`
 	} `yaml:"downstream"`
 }
 
@@ -32,6 +35,7 @@ type Config struct {
 type Server struct {
 	config     *Config
 	httpClient *http.Client
+	resilient  *resilientClient
 	router     *mux.Router
 }
 
@@ -76,12 +80,16 @@ func loadConfig(configPath string) (*Config, error) {
 
 // NewServer creates a new server instance
 func NewServer(config *Config) *Server {
+	httpClient := &http.Client{
+		Timeout:   config.Downstream.Timeout,
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	}
+
 	s := &Server{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.Downstream.Timeout,
-		},
-		router: mux.NewRouter(),
+		config:     config,
+		httpClient: httpClient,
+		resilient:  newResilientClient(httpClient, config.Downstream.URL, config.Downstream.Resilience),
+		router:     mux.NewRouter(),
 	}
 
 	s.setupRoutes()
@@ -97,7 +105,9 @@ func (s *Server) setupRoutes() {
 
 // handleCall handles the /api/v1/call endpoint
 func (s *Server) handleCall(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Received request: %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	spanCtx := trace.SpanContextFromContext(r.Context())
+	log.Printf("Received request: %s %s from %s trace_id=%s span_id=%s",
+		r.Method, r.URL.Path, r.RemoteAddr, spanCtx.TraceID(), spanCtx.SpanID())
 
 	// Parse request body if present
 	var reqBody CallRequest
@@ -157,31 +167,40 @@ func (s *Server) handleCall(w http.ResponseWriter, r *http.Request) {
 	s.sendJSON(w, statusCode, response)
 }
 
-// callDownstream makes an HTTP call to the downstream service
+// callDownstream makes a resilient HTTP call to the downstream service:
+// retries with jittered backoff, a circuit breaker, and hedged requests are
+// all applied by s.resilient per downstream.resilience in the config.
 func (s *Server) callDownstream(ctx context.Context, reqBody CallRequest) (*http.Response, error) {
 	url := s.config.Downstream.URL
 	log.Printf("Calling downstream service: %s", url)
 
-	// Create request body if present
-	var body io.Reader = http.NoBody
+	// Build the request body once; it's safe to reuse across retries/hedges
+	// since bytes.NewReader gives each attempt its own Reader.
+	var jsonBody []byte
 	if reqBody.Message != "" || len(reqBody.Data) > 0 {
-		jsonBody, err := json.Marshal(reqBody)
+		var err error
+		jsonBody, err = json.Marshal(reqBody)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		body = bytes.NewReader(jsonBody)
 		log.Printf("Sending body to downstream: %s", string(jsonBody))
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	newReq := func(reqCtx context.Context) (*http.Request, error) {
+		var body io.Reader = http.NoBody
+		if jsonBody != nil {
+			body = bytes.NewReader(jsonBody)
+		}
+		req, err := http.NewRequestWithContext(reqCtx, "POST", url, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "ms-call/1.0")
+		return req, nil
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "ms-call/1.0")
-
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.resilient.Do(ctx, newReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call downstream: %w", err)
 	}
@@ -273,7 +292,7 @@ func (s *Server) Start() error {
 	addr := ":" + s.config.Server.Port
 	srv := &http.Server{
 		Addr:         addr,
-		Handler:      s.router,
+		Handler:      otelhttp.NewHandler(s.router, "ms-call"),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -324,6 +343,18 @@ func main() {
 		config.Downstream.URL = downstreamURL
 	}
 
+	// Initialize OpenTelemetry tracing
+	tp, err := initTracer(context.Background())
+	if err != nil {
+		log.Printf("Failed to initialize tracing: %v", err)
+	} else {
+		defer func() {
+			if err := tp.Shutdown(context.Background()); err != nil {
+				log.Printf("TracerProvider shutdown error: %v", err)
+			}
+		}()
+	}
+
 	// Create and start server
 	server := NewServer(config)
 	if err := server.Start(); err != nil {
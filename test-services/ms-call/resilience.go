@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	downstreamRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "downstream_retries_total",
+			Help: "Total number of downstream call retries",
+		},
+		[]string{"endpoint"},
+	)
+
+	circuitBreakerStateTransitionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_state_transitions_total",
+			Help: "Total number of circuit breaker state transitions",
+		},
+		[]string{"endpoint", "from", "to"},
+	)
+
+	hedgedRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hedged_requests_total",
+			Help: "Total number of hedged downstream requests, by outcome",
+		},
+		[]string{"endpoint", "outcome"}, // outcome: primary_won, hedge_won
+	)
+)
+
+// ResilienceConfig configures retries, circuit breaking, and hedging for a
+// downstream endpoint. It is parsed from the downstream.resilience block.
+type ResilienceConfig struct {
+	Retry struct {
+		MaxAttempts int           `yaml:"max_attempts"`
+		BaseDelay   time.Duration `yaml:"base_delay"`
+		MaxDelay    time.Duration `yaml:"max_delay"`
+		Budget      time.Duration `yaml:"budget"`
+	} `yaml:"retry"`
+
+	CircuitBreaker struct {
+		FailureRatio     float64       `yaml:"failure_ratio"`
+		MinRequests      int           `yaml:"min_requests"`
+		OpenDuration     time.Duration `yaml:"open_duration"`
+		HalfOpenRequests int           `yaml:"half_open_requests"`
+	} `yaml:"circuit_breaker"`
+
+	Hedge struct {
+		Delay time.Duration `yaml:"delay"`
+	} `yaml:"hedge"`
+}
+
+// withDefaults fills in sane defaults for any zero-valued fields. A Config
+// that omits the resilience block makes a single attempt with no hedging;
+// the circuit breaker still applies with its default thresholds, and a 5xx
+// response is returned as an error.
+func (c *ResilienceConfig) withDefaults() {
+	if c.Retry.MaxAttempts == 0 {
+		c.Retry.MaxAttempts = 1
+	}
+	if c.Retry.BaseDelay == 0 {
+		c.Retry.BaseDelay = 100 * time.Millisecond
+	}
+	if c.Retry.MaxDelay == 0 {
+		c.Retry.MaxDelay = 2 * time.Second
+	}
+	if c.Retry.Budget == 0 {
+		c.Retry.Budget = 10 * time.Second
+	}
+	if c.CircuitBreaker.FailureRatio == 0 {
+		c.CircuitBreaker.FailureRatio = 0.5
+	}
+	if c.CircuitBreaker.MinRequests == 0 {
+		c.CircuitBreaker.MinRequests = 10
+	}
+	if c.CircuitBreaker.OpenDuration == 0 {
+		c.CircuitBreaker.OpenDuration = 30 * time.Second
+	}
+	if c.CircuitBreaker.HalfOpenRequests == 0 {
+		c.CircuitBreaker.HalfOpenRequests = 1
+	}
+}
+
+// breakerState is one of the three classic circuit breaker states.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuitBreaker is a per-endpoint failure-ratio breaker with half-open
+// probing, as described in downstream.resilience.circuit_breaker.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	cfg      ResilienceConfig
+	endpoint string
+
+	state       breakerState
+	openedAt    time.Time
+	requests    int
+	failures    int
+	halfOpenInF int
+}
+
+func newCircuitBreaker(endpoint string, cfg ResilienceConfig) *circuitBreaker {
+	return &circuitBreaker{cfg: cfg, endpoint: endpoint, state: breakerClosed}
+}
+
+// Allow reports whether a new request may proceed, transitioning
+// open -> half_open once the open duration has elapsed.
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cfg.CircuitBreaker.OpenDuration {
+			return false
+		}
+		b.transition(breakerHalfOpen)
+		b.halfOpenInF = 0
+		return true
+
+	case breakerHalfOpen:
+		if b.halfOpenInF >= b.cfg.CircuitBreaker.HalfOpenRequests {
+			return false
+		}
+		b.halfOpenInF++
+		return true
+
+	default: // breakerClosed
+		return true
+	}
+}
+
+// Record reports the outcome of a request that Allow permitted.
+func (b *circuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		if success {
+			b.transition(breakerClosed)
+			b.requests, b.failures = 0, 0
+		} else {
+			b.transition(breakerOpen)
+			b.openedAt = time.Now()
+		}
+		return
+	}
+
+	b.requests++
+	if !success {
+		b.failures++
+	}
+
+	if b.requests >= b.cfg.CircuitBreaker.MinRequests {
+		ratio := float64(b.failures) / float64(b.requests)
+		if ratio >= b.cfg.CircuitBreaker.FailureRatio {
+			b.transition(breakerOpen)
+			b.openedAt = time.Now()
+			b.requests, b.failures = 0, 0
+		}
+	}
+}
+
+// transition must be called with b.mu held.
+func (b *circuitBreaker) transition(to breakerState) {
+	if to == b.state {
+		return
+	}
+	circuitBreakerStateTransitionsTotal.WithLabelValues(b.endpoint, b.state.String(), to.String()).Inc()
+	b.state = to
+}
+
+// resilientClient wraps an *http.Client with retry, circuit breaker, and
+// hedged-request behavior, driven by a ResilienceConfig.
+type resilientClient struct {
+	httpClient *http.Client
+	cfg        ResilienceConfig
+	endpoint   string
+	breaker    *circuitBreaker
+}
+
+func newResilientClient(httpClient *http.Client, endpoint string, cfg ResilienceConfig) *resilientClient {
+	cfg.withDefaults()
+	return &resilientClient{
+		httpClient: httpClient,
+		cfg:        cfg,
+		endpoint:   endpoint,
+		breaker:    newCircuitBreaker(endpoint, cfg),
+	}
+}
+
+// Do executes newReq against the downstream endpoint, applying retries with
+// jittered exponential backoff on 5xx/timeout, hedged requests after
+// cfg.Hedge.Delay, and the circuit breaker gate in front of everything.
+func (c *resilientClient) Do(ctx context.Context, newReq func(ctx context.Context) (*http.Request, error)) (*http.Response, error) {
+	deadline := time.Now().Add(c.cfg.Retry.Budget)
+
+	var lastErr error
+	for attempt := 0; attempt < c.cfg.Retry.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if time.Now().After(deadline) {
+				break
+			}
+			downstreamRetriesTotal.WithLabelValues(c.endpoint).Inc()
+			time.Sleep(backoffWithJitter(attempt, c.cfg.Retry.BaseDelay, c.cfg.Retry.MaxDelay))
+		}
+		if !c.breaker.Allow() {
+			return nil, fmt.Errorf("circuit breaker open for %s", c.endpoint)
+		}
+
+		resp, err := c.doHedged(ctx, newReq)
+		success := err == nil && resp.StatusCode < 500
+		c.breaker.Record(success)
+
+		if success {
+			return resp, nil
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		lastErr = fmt.Errorf("downstream returned %d", resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("exhausted retries calling %s", c.endpoint)
+	}
+	return nil, lastErr
+}
+
+// doHedged fires the primary request, and if it hasn't returned within
+// cfg.Hedge.Delay, fires a second attempt in parallel, taking whichever
+// response arrives first and canceling the other.
+func (c *resilientClient) doHedged(ctx context.Context, newReq func(ctx context.Context) (*http.Request, error)) (*http.Response, error) {
+	if c.cfg.Hedge.Delay <= 0 {
+		req, err := newReq(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return c.httpClient.Do(req)
+	}
+
+	type result struct {
+		resp   *http.Response
+		err    error
+		hedged bool
+	}
+
+	primaryCtx, cancelPrimary := context.WithCancel(ctx)
+	hedgeCtx, cancelHedge := context.WithCancel(ctx)
+	resultCh := make(chan result, 2)
+
+	fire := func(fireCtx context.Context, hedged bool) {
+		req, err := newReq(fireCtx)
+		if err != nil {
+			resultCh <- result{err: err, hedged: hedged}
+			return
+		}
+		resp, err := c.httpClient.Do(req)
+		resultCh <- result{resp: resp, err: err, hedged: hedged}
+	}
+
+	go fire(primaryCtx, false)
+
+	hedgeTimer := time.NewTimer(c.cfg.Hedge.Delay)
+	defer hedgeTimer.Stop()
+
+	var hedgeFired bool
+	var first result
+	select {
+	case first = <-resultCh:
+	case <-hedgeTimer.C:
+		hedgeFired = true
+		go fire(hedgeCtx, true)
+		first = <-resultCh
+	}
+
+	// Cancel only the loser's context now. The winner's context must stay
+	// live until the caller finishes reading first.resp.Body, so it's wired
+	// up to cancel on Body.Close() instead of here.
+	var winnerCancel context.CancelFunc
+	if hedgeFired {
+		if first.hedged {
+			hedgedRequestsTotal.WithLabelValues(c.endpoint, "hedge_won").Inc()
+			cancelPrimary()
+			winnerCancel = cancelHedge
+		} else {
+			hedgedRequestsTotal.WithLabelValues(c.endpoint, "primary_won").Inc()
+			cancelHedge()
+			winnerCancel = cancelPrimary
+		}
+		go func() {
+			second := <-resultCh
+			if second.resp != nil {
+				second.resp.Body.Close()
+			}
+		}()
+	} else {
+		cancelHedge()
+		winnerCancel = cancelPrimary
+	}
+
+	if first.resp != nil {
+		first.resp.Body = &cancelOnCloseBody{ReadCloser: first.resp.Body, cancel: winnerCancel}
+	} else {
+		winnerCancel()
+	}
+
+	return first.resp, first.err
+}
+
+// cancelOnCloseBody defers canceling a hedge/primary request's context until
+// the caller is done reading its response body, instead of canceling (and
+// aborting the read) as soon as the result arrives.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// backoffWithJitter returns a full-jitter exponential backoff delay for the
+// given (1-indexed) attempt number, capped at max.
+func backoffWithJitter(attempt int, base, max time.Duration) time.Duration {
+	backoff := base * time.Duration(1<<uint(attempt-1))
+	if backoff > max {
+		backoff = max
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// initTracer wires up a global TracerProvider that exports spans via OTLP/HTTP
+// to OTEL_EXPORTER_OTLP_ENDPOINT, and installs the W3C trace context
+// propagator so traceparent/tracestate headers flow across services.
+func initTracer(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res, err := sdkresource.New(ctx,
+		sdkresource.WithAttributes(
+			semconv.ServiceName("ms-call"),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build otel resource: %w", err)
+	}
+
+	// OTEL_EXPORTER_OTLP_ENDPOINT is a full URL (e.g. http://collector:4318),
+	// not a bare host:port, so it must go through WithEndpointURL rather than
+	// WithEndpoint. WithEndpointURL also derives insecure-vs-TLS from the
+	// URL's scheme, so WithInsecure is only needed for the no-endpoint default.
+	var opts []otlptracehttp.Option
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+	} else {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp, nil
+}